@@ -0,0 +1,45 @@
+// Copyright 2019 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/prometheus/common/model"
+)
+
+// AuthTokenSource configures where the XOAUTH2 bearer token comes from.
+// Either Token or TokenFile must be set; when TokenFile is set the token is
+// re-read from disk at most once per RefreshInterval so that an external
+// process can rotate it.
+type AuthTokenSource struct {
+	Token           Secret         `yaml:"token,omitempty" json:"token,omitempty"`
+	TokenFile       string         `yaml:"token_file,omitempty" json:"token_file,omitempty"`
+	RefreshInterval model.Duration `yaml:"refresh_interval,omitempty" json:"refresh_interval,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface for AuthTokenSource.
+func (c *AuthTokenSource) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain AuthTokenSource
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if len(c.Token) == 0 && c.TokenFile == "" {
+		return fmt.Errorf("must set either token or token_file in auth_token_source")
+	}
+	if len(c.Token) > 0 && c.TokenFile != "" {
+		return fmt.Errorf("at most one of token and token_file can be set in auth_token_source")
+	}
+	return nil
+}
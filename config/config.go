@@ -0,0 +1,140 @@
+// Copyright 2019 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	commoncfg "github.com/prometheus/common/config"
+)
+
+const secretToken = "<secret>"
+
+// Secret is a string that must not be revealed on marshaling.
+type Secret string
+
+// NewSecret wraps s so that it is not revealed on marshaling.
+func NewSecret(s string) Secret {
+	return Secret(s)
+}
+
+// MarshalYAML implements the yaml.Marshaler interface for Secret.
+func (s Secret) MarshalYAML() (interface{}, error) {
+	if len(s) == 0 {
+		return "", nil
+	}
+	return secretToken, nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface for Secret.
+func (s *Secret) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain Secret
+	return unmarshal((*plain)(s))
+}
+
+// NotifierConfig is the base config for all notifiers.
+type NotifierConfig struct {
+	VSendResolved bool `yaml:"send_resolved,omitempty" json:"send_resolved,omitempty"`
+}
+
+// SendResolved implements the Notifier interface.
+func (nc *NotifierConfig) SendResolved() bool {
+	return nc.VSendResolved
+}
+
+// Receiver configures one or more notification integrations.
+type Receiver struct {
+	Name string `yaml:"name" json:"name"`
+
+	EmailConfigs   []*EmailConfig   `yaml:"email_configs,omitempty" json:"email_configs,omitempty"`
+	MailgunConfigs []*MailgunConfig `yaml:"mailgun_configs,omitempty" json:"mailgun_configs,omitempty"`
+	SMSConfigs     []*SMSConfig     `yaml:"sms_configs,omitempty" json:"sms_configs,omitempty"`
+}
+
+// DefaultEmailConfig holds the default values for EmailConfig. RequireTLS
+// isn't defaulted here, since it's a *bool shared across every unmarshaled
+// config: defaulting it in UnmarshalYAML after the fact, once we know
+// whether it was left unset, avoids every config pointing at (and
+// potentially writing through) the same bool.
+var DefaultEmailConfig = EmailConfig{
+	NotifierConfig: NotifierConfig{
+		VSendResolved: false,
+	},
+	HTML: `{{ template "email.default.html" . }}`,
+	Text: ``,
+}
+
+// EmailConfig configures notifications via mail.
+type EmailConfig struct {
+	NotifierConfig `yaml:",inline" json:",inline"`
+
+	To           string `yaml:"to,omitempty" json:"to,omitempty"`
+	From         string `yaml:"from,omitempty" json:"from,omitempty"`
+	Hello        string `yaml:"hello,omitempty" json:"hello,omitempty"`
+	Smarthost    string `yaml:"smarthost,omitempty" json:"smarthost,omitempty"`
+	AuthUsername string `yaml:"auth_username,omitempty" json:"auth_username,omitempty"`
+	AuthPassword Secret `yaml:"auth_password,omitempty" json:"auth_password,omitempty"`
+	AuthSecret   Secret `yaml:"auth_secret,omitempty" json:"auth_secret,omitempty"`
+	AuthIdentity string `yaml:"auth_identity,omitempty" json:"auth_identity,omitempty"`
+	// AuthTokenSource configures the bearer token used for the XOAUTH2 auth
+	// mechanism, for relays (Gmail, Office365) that don't accept plain
+	// passwords.
+	AuthTokenSource *AuthTokenSource  `yaml:"auth_token_source,omitempty" json:"auth_token_source,omitempty"`
+	Headers         map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	HTML            string            `yaml:"html,omitempty" json:"html,omitempty"`
+	Text            string            `yaml:"text,omitempty" json:"text,omitempty"`
+	RequireTLS      *bool             `yaml:"require_tls,omitempty" json:"require_tls,omitempty"`
+	// SMTPS connects to the smarthost over implicit TLS (port 465) instead
+	// of negotiating STARTTLS after connecting in plaintext. It is
+	// mutually exclusive with RequireTLS, which only makes sense for the
+	// STARTTLS handshake.
+	SMTPS     bool                `yaml:"smtps,omitempty" json:"smtps,omitempty"`
+	TLSConfig commoncfg.TLSConfig `yaml:"tls_config,omitempty" json:"tls_config,omitempty"`
+	// DKIM signs outgoing messages with the given key, for relays that
+	// reject unsigned mail. Nil disables signing.
+	DKIM *DKIMConfig `yaml:"dkim,omitempty" json:"dkim,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface for EmailConfig.
+func (c *EmailConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultEmailConfig
+	type plain EmailConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if len(c.To) == 0 {
+		return fmt.Errorf("missing to address in email config")
+	}
+	if c.SMTPS && c.RequireTLS != nil && *c.RequireTLS {
+		return fmt.Errorf("at most one of smtps and require_tls can be set in email config")
+	}
+	if c.RequireTLS == nil && !c.SMTPS {
+		// STARTTLS is required by default; smtps already secures the
+		// connection, so the STARTTLS-specific default doesn't apply there.
+		requireTLS := true
+		c.RequireTLS = &requireTLS
+	}
+	// Header names are case-insensitive, check for collisions.
+	normalizedHeaders := map[string]string{}
+	for h, v := range c.Headers {
+		normalized := strings.Title(h)
+		if _, ok := normalizedHeaders[normalized]; ok {
+			return fmt.Errorf("duplicate header %q in email config", normalized)
+		}
+		normalizedHeaders[normalized] = v
+	}
+	c.Headers = normalizedHeaders
+	return nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2019 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestEmailConfigSMTPSAndRequireTLSMutuallyExclusive(t *testing.T) {
+	var c EmailConfig
+	err := yaml.Unmarshal([]byte(`
+to: alerts@example.com
+smtps: true
+require_tls: true
+`), &c)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "at most one of smtps and require_tls")
+}
+
+func TestEmailConfigRequireTLSDefaultsTrue(t *testing.T) {
+	var c EmailConfig
+	err := yaml.Unmarshal([]byte(`
+to: alerts@example.com
+`), &c)
+	require.NoError(t, err)
+	require.NotNil(t, c.RequireTLS)
+	require.True(t, *c.RequireTLS)
+}
+
+func TestEmailConfigSMTPSAlone(t *testing.T) {
+	var c EmailConfig
+	err := yaml.Unmarshal([]byte(`
+to: alerts@example.com
+smtps: true
+`), &c)
+	require.NoError(t, err)
+	require.True(t, c.SMTPS)
+}
+
+func TestEmailConfigDKIM(t *testing.T) {
+	var c EmailConfig
+	err := yaml.Unmarshal([]byte(`
+to: alerts@example.com
+dkim:
+  selector: alertmanager
+  domain: example.com
+  private_key_file: /etc/alertmanager/dkim.pem
+`), &c)
+	require.NoError(t, err)
+	require.Equal(t, DefaultDKIMHeaders, c.DKIM.Headers)
+	require.Equal(t, "relaxed/relaxed", c.DKIM.Canonicalization)
+}
+
+func TestDKIMConfigMissingFields(t *testing.T) {
+	for name, yamlDoc := range map[string]string{
+		"missing selector":         "domain: example.com\nprivate_key_file: /etc/dkim.pem\n",
+		"missing domain":           "selector: am\nprivate_key_file: /etc/dkim.pem\n",
+		"missing private_key_file": "selector: am\ndomain: example.com\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			var c DKIMConfig
+			err := yaml.Unmarshal([]byte(yamlDoc), &c)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestDKIMConfigUnsupportedCanonicalization(t *testing.T) {
+	var c DKIMConfig
+	err := yaml.Unmarshal([]byte(`
+selector: am
+domain: example.com
+private_key_file: /etc/dkim.pem
+canonicalization: simple/simple
+`), &c)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "only relaxed/relaxed is supported")
+}
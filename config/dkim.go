@@ -0,0 +1,69 @@
+// Copyright 2019 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import "fmt"
+
+// DefaultDKIMHeaders are the headers signed when DKIMConfig.Headers is unset.
+var DefaultDKIMHeaders = []string{"From", "To", "Subject", "Date", "Message-Id"}
+
+// DefaultDKIMConfig holds the default values for DKIMConfig.
+var DefaultDKIMConfig = DKIMConfig{
+	Headers:          DefaultDKIMHeaders,
+	Canonicalization: "relaxed/relaxed",
+}
+
+// DKIMConfig configures DKIM signing of outgoing email notifications.
+type DKIMConfig struct {
+	// Selector and Domain identify the public key published in DNS as
+	// "<selector>._domainkey.<domain>".
+	Selector string `yaml:"selector" json:"selector"`
+	Domain   string `yaml:"domain" json:"domain"`
+
+	// PrivateKeyFile is a PEM-encoded RSA private key, in either PKCS#1 or
+	// PKCS#8 form. It is loaded and parsed once, at notifier construction
+	// time, so a bad key is a configuration error rather than a per-email
+	// failure.
+	PrivateKeyFile string `yaml:"private_key_file" json:"private_key_file"`
+
+	// Headers lists the message headers included in the signature. They are
+	// signed in the order they appear in the message, not in this order.
+	Headers []string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// Canonicalization selects the header/body canonicalization algorithms,
+	// as "<header>/<body>". Only "relaxed/relaxed" is currently supported.
+	Canonicalization string `yaml:"canonicalization,omitempty" json:"canonicalization,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface for DKIMConfig.
+func (c *DKIMConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultDKIMConfig
+	type plain DKIMConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Selector == "" {
+		return fmt.Errorf("missing selector in dkim config")
+	}
+	if c.Domain == "" {
+		return fmt.Errorf("missing domain in dkim config")
+	}
+	if c.PrivateKeyFile == "" {
+		return fmt.Errorf("missing private_key_file in dkim config")
+	}
+	if c.Canonicalization != "relaxed/relaxed" {
+		return fmt.Errorf("unsupported dkim canonicalization %q: only relaxed/relaxed is supported", c.Canonicalization)
+	}
+	return nil
+}
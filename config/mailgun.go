@@ -0,0 +1,90 @@
+// Copyright 2019 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+
+	commoncfg "github.com/prometheus/common/config"
+)
+
+// DefaultMailgunConfig holds the default values for MailgunConfig.
+var DefaultMailgunConfig = MailgunConfig{
+	NotifierConfig: NotifierConfig{
+		VSendResolved: true,
+	},
+	Region:     "us",
+	HTML:       `{{ template "email.default.html" . }}`,
+	Tracking:   true,
+	HTTPConfig: &commoncfg.HTTPClientConfig{},
+}
+
+// MailgunConfig configures notifications via the Mailgun HTTP API, for
+// installs that don't have a reliable outbound SMTP path (e.g. serverless or
+// Kubernetes deployments).
+type MailgunConfig struct {
+	NotifierConfig `yaml:",inline" json:",inline"`
+
+	// APIKey authenticates against the Mailgun HTTP API.
+	APIKey Secret `yaml:"api_key" json:"api_key"`
+	// Domain is the sending domain registered with Mailgun.
+	Domain string `yaml:"domain" json:"domain"`
+	// Region selects the API host, "us" or "eu".
+	Region string `yaml:"region,omitempty" json:"region,omitempty"`
+
+	To      string            `yaml:"to,omitempty" json:"to,omitempty"`
+	From    string            `yaml:"from,omitempty" json:"from,omitempty"`
+	Subject string            `yaml:"subject,omitempty" json:"subject,omitempty"`
+	HTML    string            `yaml:"html,omitempty" json:"html,omitempty"`
+	Text    string            `yaml:"text,omitempty" json:"text,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	Tags    []string          `yaml:"tags,omitempty" json:"tags,omitempty"`
+	// Tracking enables Mailgun's open/click tracking ("o:tracking").
+	Tracking bool `yaml:"tracking" json:"tracking"`
+
+	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
+}
+
+// mailgunAPIHosts maps a region to its Mailgun API host.
+var mailgunAPIHosts = map[string]string{
+	"us": "api.mailgun.net",
+	"eu": "api.eu.mailgun.net",
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface for MailgunConfig.
+func (c *MailgunConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultMailgunConfig
+	type plain MailgunConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if len(c.To) == 0 {
+		return fmt.Errorf("missing to address in mailgun config")
+	}
+	if len(c.Domain) == 0 {
+		return fmt.Errorf("missing domain in mailgun config")
+	}
+	if len(c.APIKey) == 0 {
+		return fmt.Errorf("missing api_key in mailgun config")
+	}
+	if _, ok := mailgunAPIHosts[c.Region]; !ok {
+		return fmt.Errorf("unknown mailgun region %q", c.Region)
+	}
+	return nil
+}
+
+// APIHost returns the Mailgun API host for the configured region.
+func (c *MailgunConfig) APIHost() string {
+	return mailgunAPIHosts[c.Region]
+}
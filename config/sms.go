@@ -0,0 +1,97 @@
+// Copyright 2019 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+
+	commoncfg "github.com/prometheus/common/config"
+)
+
+// SMSProvider selects which SMS gateway an SMSConfig talks to.
+type SMSProvider string
+
+// Supported SMS providers.
+const (
+	SMSProviderTwilio         SMSProvider = "twilio"
+	SMSProviderMessageBird    SMSProvider = "messagebird"
+	SMSProviderVonage         SMSProvider = "vonage"
+	SMSProviderGenericWebhook SMSProvider = "generic-webhook"
+)
+
+// DefaultSMSConfig holds the default values for SMSConfig.
+var DefaultSMSConfig = SMSConfig{
+	NotifierConfig: NotifierConfig{
+		VSendResolved: true,
+	},
+	Provider:   SMSProviderTwilio,
+	Body:       `{{ template "sms.default.body" . }}`,
+	HTTPConfig: &commoncfg.HTTPClientConfig{},
+}
+
+// SMSConfig configures notifications via SMS, following the same
+// provider-pluggable pattern as EmailConfig does for mail.
+type SMSConfig struct {
+	NotifierConfig `yaml:",inline" json:",inline"`
+
+	Provider SMSProvider `yaml:"provider,omitempty" json:"provider,omitempty"`
+
+	// To is a comma-separated list of destination numbers; the message is
+	// faned out to each of them individually.
+	To   string `yaml:"to,omitempty" json:"to,omitempty"`
+	From string `yaml:"from,omitempty" json:"from,omitempty"`
+	Body string `yaml:"body,omitempty" json:"body,omitempty"`
+
+	TwilioAccountSID Secret `yaml:"twilio_account_sid,omitempty" json:"twilio_account_sid,omitempty"`
+	TwilioAuthToken  Secret `yaml:"twilio_auth_token,omitempty" json:"twilio_auth_token,omitempty"`
+
+	MessageBirdAccessKey Secret `yaml:"messagebird_access_key,omitempty" json:"messagebird_access_key,omitempty"`
+
+	VonageAPIKey    Secret `yaml:"vonage_api_key,omitempty" json:"vonage_api_key,omitempty"`
+	VonageAPISecret Secret `yaml:"vonage_api_secret,omitempty" json:"vonage_api_secret,omitempty"`
+
+	WebhookURL Secret `yaml:"webhook_url,omitempty" json:"webhook_url,omitempty"`
+
+	// APIURL overrides the provider's default endpoint so tests can point
+	// it at a local mock server.
+	APIURL string `yaml:"api_url,omitempty" json:"api_url,omitempty"`
+
+	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface for SMSConfig.
+func (c *SMSConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultSMSConfig
+	type plain SMSConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if len(c.To) == 0 {
+		return fmt.Errorf("missing to number(s) in sms config")
+	}
+	switch c.Provider {
+	case SMSProviderTwilio:
+		if len(c.TwilioAccountSID) == 0 || len(c.TwilioAuthToken) == 0 {
+			return fmt.Errorf("missing twilio_account_sid or twilio_auth_token in sms config")
+		}
+	case SMSProviderMessageBird, SMSProviderVonage, SMSProviderGenericWebhook:
+		// These providers are recognized but not yet implemented by the SMS
+		// notifier; reject them here instead of accepting a config that will
+		// fail every send.
+		return fmt.Errorf("sms provider %q is not yet implemented", c.Provider)
+	default:
+		return fmt.Errorf("unknown sms provider %q", c.Provider)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2019 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// TestSMSConfigUnimplementedProvidersRejected checks that providers the SMS
+// notifier doesn't yet implement are caught at config-load time rather than
+// passing validation and failing every send.
+func TestSMSConfigUnimplementedProvidersRejected(t *testing.T) {
+	for provider, yamlDoc := range map[SMSProvider]string{
+		SMSProviderMessageBird:    "to: \"+15551234567\"\nprovider: messagebird\nmessagebird_access_key: key\n",
+		SMSProviderVonage:         "to: \"+15551234567\"\nprovider: vonage\nvonage_api_key: key\nvonage_api_secret: secret\n",
+		SMSProviderGenericWebhook: "to: \"+15551234567\"\nprovider: generic-webhook\nwebhook_url: https://example.com/hook\n",
+	} {
+		t.Run(string(provider), func(t *testing.T) {
+			var c SMSConfig
+			err := yaml.Unmarshal([]byte(yamlDoc), &c)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "is not yet implemented")
+		})
+	}
+}
+
+func TestSMSConfigTwilioStillAccepted(t *testing.T) {
+	var c SMSConfig
+	err := yaml.Unmarshal([]byte(`
+to: "+15551234567"
+twilio_account_sid: SID
+twilio_auth_token: TOKEN
+`), &c)
+	require.NoError(t, err)
+	require.Equal(t, SMSProviderTwilio, c.Provider)
+}
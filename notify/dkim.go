@@ -0,0 +1,163 @@
+// Copyright 2019 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/alertmanager/config"
+)
+
+// mimeHeader is a single rendered message header, in the order it appears
+// in the outgoing message.
+type mimeHeader struct {
+	name  string
+	value string
+}
+
+// dkimSigner signs outgoing messages with a cached, pre-parsed RSA private
+// key. Only the "relaxed/relaxed" canonicalization from RFC 6376 is
+// supported, which is enforced by config.DKIMConfig.UnmarshalYAML.
+type dkimSigner struct {
+	conf *config.DKIMConfig
+	key  *rsa.PrivateKey
+}
+
+// newDKIMSigner loads and parses the private key referenced by c, failing
+// closed: a malformed key is a configuration error to surface at notifier
+// construction time, not something to retry while sending mail. It returns
+// a nil signer if c is nil.
+func newDKIMSigner(c *config.DKIMConfig) (*dkimSigner, error) {
+	if c == nil {
+		return nil, nil
+	}
+	raw, err := ioutil.ReadFile(c.PrivateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading dkim private key: %w", err)
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found in dkim private key %q", c.PrivateKeyFile)
+	}
+	key, err := parseRSAPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing dkim private key: %w", err)
+	}
+	return &dkimSigner{conf: c, key: key}, nil
+}
+
+// parseRSAPrivateKey accepts both PKCS#1 ("BEGIN RSA PRIVATE KEY") and
+// PKCS#8 ("BEGIN PRIVATE KEY") encodings, since both are common output
+// formats for the openssl invocations used to generate DKIM keys.
+func parseRSAPrivateKey(der []byte) (*rsa.PrivateKey, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("dkim private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// sign returns the value of a DKIM-Signature header (everything after
+// "DKIM-Signature: ", without a trailing CRLF) covering headers and body,
+// using relaxed/relaxed canonicalization and RSA-SHA256.
+func (s *dkimSigner) sign(headers []mimeHeader, body []byte) (string, error) {
+	bodyHash := sha256.Sum256(canonicalizeBodyRelaxed(body))
+
+	var (
+		signedNames []string
+		canon       bytes.Buffer
+	)
+	for _, h := range headers {
+		if !containsFold(s.conf.Headers, h.name) {
+			continue
+		}
+		signedNames = append(signedNames, strings.ToLower(h.name))
+		canon.WriteString(canonicalizeHeaderRelaxed(h.name, h.value))
+	}
+
+	sigValue := fmt.Sprintf(
+		"v=1; a=rsa-sha256; c=relaxed/relaxed; d=%s; s=%s; h=%s; bh=%s; b=",
+		s.conf.Domain, s.conf.Selector, strings.Join(signedNames, ":"),
+		base64.StdEncoding.EncodeToString(bodyHash[:]),
+	)
+	// The DKIM-Signature header itself is part of the signed input, with
+	// an empty b= value and no trailing CRLF (RFC 6376 section 3.7).
+	canon.WriteString(canonicalizeHeaderRelaxed("DKIM-Signature", sigValue))
+	signingInput := bytes.TrimSuffix(canon.Bytes(), []byte("\r\n"))
+
+	digest := sha256.Sum256(signingInput)
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("signing dkim header: %w", err)
+	}
+	return sigValue + base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// containsFold reports whether name is present in names, ignoring case.
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+var wspRun = regexp.MustCompile(`[ \t]+`)
+
+// canonicalizeHeaderRelaxed applies RFC 6376 section 3.4.2 "relaxed" header
+// canonicalization to a single header.
+func canonicalizeHeaderRelaxed(name, value string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	value = strings.ReplaceAll(value, "\r\n", "")
+	value = wspRun.ReplaceAllString(value, " ")
+	value = strings.TrimSpace(value)
+	return name + ":" + value + "\r\n"
+}
+
+// canonicalizeBodyRelaxed applies RFC 6376 section 3.4.4 "relaxed" body
+// canonicalization: trailing whitespace is stripped from every line,
+// interior whitespace runs are collapsed to a single space, and the body
+// is reduced to a single trailing CRLF (no trailing blank lines).
+func canonicalizeBodyRelaxed(body []byte) []byte {
+	lines := strings.Split(string(body), "\r\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(wspRun.ReplaceAllString(l, " "), " \t")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return []byte("\r\n")
+	}
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
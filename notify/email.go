@@ -0,0 +1,420 @@
+// Copyright 2019 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	commoncfg "github.com/prometheus/common/config"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// DefaultEmailSubject is used when no subject header is configured.
+const DefaultEmailSubject = `{{ template "email.default.subject" . }}`
+
+// SMTPClient is the subset of *smtp.Client that Email.Notify depends on. It
+// exists so that tests can exercise the notifier against a scripted mock
+// instead of a real SMTP server; *smtp.Client satisfies it as-is.
+type SMTPClient interface {
+	Hello(localName string) error
+	Extension(ext string) (bool, string)
+	StartTLS(config *tls.Config) error
+	Auth(a smtp.Auth) error
+	Mail(from string) error
+	Rcpt(to string) error
+	Data() (io.WriteCloser, error)
+	Quit() error
+	Close() error
+}
+
+// Email notifier.
+type Email struct {
+	conf   *config.EmailConfig
+	tmpl   *template.Template
+	logger log.Logger
+
+	// dialer opens the SMTPClient used to deliver the message. Tests
+	// override it to avoid talking to a real network.
+	dialer func(ctx context.Context, conf *config.EmailConfig) (SMTPClient, error)
+
+	// dkim signs the outgoing message if conf.DKIM is set, nil otherwise.
+	dkim *dkimSigner
+}
+
+// NewEmail returns a new Email notifier. It fails closed if conf.DKIM is
+// set but its private key cannot be loaded and parsed.
+func NewEmail(c *config.EmailConfig, t *template.Template, l log.Logger) (*Email, error) {
+	if _, ok := c.Headers["Subject"]; !ok {
+		if c.Headers == nil {
+			c.Headers = make(map[string]string)
+		}
+		c.Headers["Subject"] = DefaultEmailSubject
+	}
+	dkim, err := newDKIMSigner(c.DKIM)
+	if err != nil {
+		return nil, err
+	}
+	return &Email{conf: c, tmpl: t, logger: l, dialer: dialSMTP, dkim: dkim}, nil
+}
+
+// dialSMTP is the default Email.dialer: for SMTPS it opens an implicit-TLS
+// connection to the smarthost (as used on port 465 by SES, Fastmail and
+// some corporate relays); otherwise it opens a plain TCP connection,
+// leaving STARTTLS negotiation to Notify. Either way it wraps the
+// connection in a *smtp.Client.
+func dialSMTP(ctx context.Context, conf *config.EmailConfig) (SMTPClient, error) {
+	smarthost, _, err := net.SplitHostPort(conf.Smarthost)
+	if err != nil {
+		return nil, fmt.Errorf("invalid smarthost: %w", err)
+	}
+
+	var conn net.Conn
+	if conf.SMTPS {
+		tlsConf, err := commoncfg.NewTLSConfig(&conf.TLSConfig)
+		if err != nil {
+			return nil, fmt.Errorf("parse TLS config: %w", err)
+		}
+		if tlsConf.ServerName == "" {
+			tlsConf.ServerName = smarthost
+		}
+		conn, err = (&tls.Dialer{Config: tlsConf}).DialContext(ctx, "tcp", conf.Smarthost)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", conf.Smarthost)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := smtp.NewClient(conn, smarthost)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// auth resolves a string of space separated SMTP auth mechanisms advertised
+// by the server into a smtp.Auth implementation, preferring the first
+// mechanism it has credentials for.
+func (n *Email) auth(mechs string) (smtp.Auth, error) {
+	username := n.conf.AuthUsername
+	if username == "" {
+		return nil, nil
+	}
+
+	var errs []string
+	for _, mech := range strings.Split(mechs, " ") {
+		switch mech {
+		case "CRAM-MD5":
+			secret := string(n.conf.AuthSecret)
+			if secret == "" {
+				errs = append(errs, "missing secret for CRAM-MD5 auth mechanism")
+				continue
+			}
+			return smtp.CRAMMD5Auth(username, secret), nil
+
+		case "PLAIN":
+			password := string(n.conf.AuthPassword)
+			if password == "" {
+				errs = append(errs, "missing password for PLAIN auth mechanism")
+				continue
+			}
+			host, _, err := net.SplitHostPort(n.conf.Smarthost)
+			if err != nil {
+				host = n.conf.Smarthost
+			}
+			return smtp.PlainAuth(n.conf.AuthIdentity, username, password, host), nil
+
+		case "LOGIN":
+			password := string(n.conf.AuthPassword)
+			if password == "" {
+				errs = append(errs, "missing password for LOGIN auth mechanism")
+				continue
+			}
+			return &loginAuth{username: username, password: password}, nil
+
+		case "XOAUTH2":
+			if n.conf.AuthTokenSource == nil {
+				errs = append(errs, "missing auth_token_source for XOAUTH2 auth mechanism")
+				continue
+			}
+			source, err := newBearerTokenSource(n.conf.AuthTokenSource)
+			if err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			return &xoauth2Auth{username: username, source: source}, nil
+
+		default:
+			errs = append(errs, fmt.Sprintf("unknown auth mechanism: %s", mech))
+		}
+	}
+	return nil, errors.New(strings.Join(errs, "; "))
+}
+
+// loginAuth implements the (undocumented) LOGIN SMTP auth mechanism used by
+// some SMTP relays in place of PLAIN.
+type loginAuth struct {
+	username string
+	password string
+}
+
+func (a *loginAuth) Start(_ *smtp.ServerInfo) (string, []byte, error) {
+	return "LOGIN", []byte(a.username), nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(string(fromServer)) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected server challenge: %s", fromServer)
+	}
+}
+
+// Notify implements the Notifier interface.
+func (n *Email) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	data := n.tmpl.Data("email", as...)
+
+	// Someone sending emails can have a single From address with multiple
+	// recipients but not the other way around.
+	if len(strings.Split(n.conf.From, ",")) > 1 {
+		return false, fmt.Errorf("must be exactly one from address")
+	}
+
+	smarthost, _, err := net.SplitHostPort(n.conf.Smarthost)
+	if err != nil {
+		return false, fmt.Errorf("invalid smarthost: %w", err)
+	}
+
+	client, err := n.dialer(ctx, n.conf)
+	if err != nil {
+		return true, err
+	}
+	defer client.Close()
+
+	hello := n.conf.Hello
+	if hello == "" {
+		hello = "localhost"
+	}
+	if err := client.Hello(hello); err != nil {
+		return true, err
+	}
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		tlsConf, err := commoncfg.NewTLSConfig(&n.conf.TLSConfig)
+		if err != nil {
+			return false, fmt.Errorf("parse TLS config: %w", err)
+		}
+		if tlsConf.ServerName == "" {
+			tlsConf.ServerName = smarthost
+		}
+		if err := client.StartTLS(tlsConf); err != nil {
+			return true, err
+		}
+	} else if n.conf.RequireTLS != nil && *n.conf.RequireTLS {
+		return true, fmt.Errorf("'require_tls' is true (default) but %q does not advertise the STARTTLS extension", n.conf.Smarthost)
+	}
+
+	if ok, mechs := client.Extension("AUTH"); ok {
+		auth, err := n.auth(mechs)
+		if err != nil {
+			return true, err
+		}
+		if auth != nil {
+			if err := client.Auth(auth); err != nil {
+				return true, fmt.Errorf("%T failed: %w", auth, err)
+			}
+		}
+	}
+
+	var (
+		tmplErr  error
+		tmplText = func(name, tmpl string) string {
+			if tmplErr != nil {
+				return ""
+			}
+			v, err := n.tmpl.ExecuteTextString(tmpl, data)
+			if err != nil {
+				tmplErr = fmt.Errorf("executing %q: %w", name, err)
+			}
+			return v
+		}
+	)
+
+	from, err := mail.ParseAddress(n.conf.From)
+	if err != nil {
+		return false, fmt.Errorf("parse from address: %w", err)
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return true, err
+	}
+
+	to := strings.Split(n.conf.To, ",")
+	for _, addr := range to {
+		a, err := mail.ParseAddress(strings.TrimSpace(addr))
+		if err != nil {
+			return false, fmt.Errorf("parse to address %q: %w", addr, err)
+		}
+		if err := client.Rcpt(a.Address); err != nil {
+			return true, err
+		}
+	}
+
+	message, err := client.Data()
+	if err != nil {
+		return true, err
+	}
+	defer message.Close()
+
+	var (
+		boundary        = fmt.Sprintf("%x", time.Now().UnixNano())
+		bodyBuffer      = &bytes.Buffer{}
+		multipartWriter = multipart.NewWriter(bodyBuffer)
+		headers         []mimeHeader
+	)
+	multipartWriter.SetBoundary(boundary)
+
+	addHeader := func(name, value string) {
+		headers = append(headers, mimeHeader{name: name, value: value})
+	}
+
+	addHeader("To", n.conf.To)
+	addHeader("From", n.conf.From)
+	for header, value := range n.conf.Headers {
+		value = tmplText(header, value)
+		if tmplErr != nil {
+			return false, fmt.Errorf("execute %q header: %w", header, tmplErr)
+		}
+		addHeader(header, mime.QEncoding.Encode("utf-8", value))
+	}
+	addHeader("Date", time.Now().Format(time.RFC1123Z))
+	addHeader("Message-Id", generateMessageID(n.conf.From))
+	addHeader("Content-Type", fmt.Sprintf("multipart/alternative;  boundary=%s", boundary))
+	addHeader("MIME-Version", "1.0")
+
+	if len(n.conf.Text) > 0 {
+		w, err := multipartWriter.CreatePart(textproto.MIMEHeader{
+			"Content-Transfer-Encoding": {"quoted-printable"},
+			"Content-Type":              {"text/plain; charset=UTF-8"},
+		})
+		if err != nil {
+			return false, fmt.Errorf("creating part for text template: %w", err)
+		}
+		body := tmplText("text", n.conf.Text)
+		if tmplErr != nil {
+			return false, fmt.Errorf("executing text template: %w", tmplErr)
+		}
+		qw := quotedprintable.NewWriter(w)
+		_, err = qw.Write([]byte(body))
+		if err != nil {
+			return false, fmt.Errorf("writing text part: %w", err)
+		}
+		if err := qw.Close(); err != nil {
+			return false, fmt.Errorf("closing text part: %w", err)
+		}
+	}
+
+	if len(n.conf.HTML) > 0 {
+		w, err := multipartWriter.CreatePart(textproto.MIMEHeader{
+			"Content-Transfer-Encoding": {"quoted-printable"},
+			"Content-Type":              {"text/html; charset=UTF-8"},
+		})
+		if err != nil {
+			return false, fmt.Errorf("creating part for html template: %w", err)
+		}
+		body, err := n.tmpl.ExecuteHTMLString(n.conf.HTML, data)
+		if err != nil {
+			return false, fmt.Errorf("executing html template: %w", err)
+		}
+		qw := quotedprintable.NewWriter(w)
+		_, err = qw.Write([]byte(body))
+		if err != nil {
+			return false, fmt.Errorf("writing html part: %w", err)
+		}
+		if err := qw.Close(); err != nil {
+			return false, fmt.Errorf("closing html part: %w", err)
+		}
+	}
+
+	if err := multipartWriter.Close(); err != nil {
+		return false, fmt.Errorf("closing multipart writer: %w", err)
+	}
+
+	if n.dkim != nil {
+		sig, err := n.dkim.sign(headers, bodyBuffer.Bytes())
+		if err != nil {
+			return false, fmt.Errorf("signing email: %w", err)
+		}
+		headers = append([]mimeHeader{{name: "DKIM-Signature", value: sig}}, headers...)
+	}
+
+	buffer := &bytes.Buffer{}
+	for _, h := range headers {
+		fmt.Fprintf(buffer, "%s: %s\r\n", h.name, h.value)
+	}
+	buffer.WriteString("\r\n")
+	buffer.Write(bodyBuffer.Bytes())
+
+	if _, err := message.Write(buffer.Bytes()); err != nil {
+		return true, err
+	}
+	if err := message.Close(); err != nil {
+		return true, err
+	}
+
+	level.Debug(n.logger).Log("msg", "sent email notification", "to", n.conf.To)
+
+	return false, client.Quit()
+}
+
+// generateMessageID returns an RFC 5322 Message-ID value using the domain
+// of from, falling back to "localhost" if it can't be parsed.
+func generateMessageID(from string) string {
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if i := strings.LastIndex(addr.Address, "@"); i != -1 {
+			domain = addr.Address[i+1:]
+		}
+	}
+	b := make([]byte, 8)
+	rand.Read(b)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(b), domain)
+}
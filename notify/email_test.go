@@ -33,6 +33,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/smtp"
 	"net/url"
 	"os"
 	"strings"
@@ -51,8 +52,10 @@ import (
 )
 
 const (
-	emailNoAuthConfigVar = "EMAIL_NO_AUTH_CONFIG"
-	emailAuthConfigVar   = "EMAIL_AUTH_CONFIG"
+	emailNoAuthConfigVar  = "EMAIL_NO_AUTH_CONFIG"
+	emailAuthConfigVar    = "EMAIL_AUTH_CONFIG"
+	emailXOAUTH2ConfigVar = "EMAIL_XOAUTH2_CONFIG"
+	emailSMTPSConfigVar   = "EMAIL_SMTPS_CONFIG"
 
 	emailTo   = "alerts@example.com"
 	emailFrom = "alertmanager@example.com"
@@ -184,7 +187,10 @@ func notifyEmail(cfg *config.EmailConfig, server *mailDev) (*email, bool, error)
 		return nil, false, err
 	}
 	tmpl.ExternalURL, _ = url.Parse("http://am")
-	email := NewEmail(cfg, tmpl, log.NewNopLogger())
+	email, err := NewEmail(cfg, tmpl, log.NewNopLogger())
+	if err != nil {
+		return nil, false, err
+	}
 
 	ctx := context.Background()
 	retry, err := email.Notify(ctx, firingAlert)
@@ -256,6 +262,75 @@ func TestEmailNotifyWithSTARTTLS(t *testing.T) {
 	require.NoError(t, err)
 }
 
+// TestEmailNotifyWithSMTPS connects to a TLS-only server on port 465 (as
+// used by SES, Fastmail and some corporate relays) via implicit TLS, sends
+// an email then it checks that the server has successfully processed it.
+func TestEmailNotifyWithSMTPS(t *testing.T) {
+	cfgFile := os.Getenv(emailSMTPSConfigVar)
+	if len(cfgFile) == 0 {
+		t.Skipf("%s not set", emailSMTPSConfigVar)
+	}
+
+	c, err := loadEmailTestConfiguration(cfgFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = notifyEmail(
+		&config.EmailConfig{
+			Smarthost: c.Smarthost,
+			To:        emailTo,
+			From:      emailFrom,
+			HTML:      "HTML body",
+			Text:      "Text body",
+			SMTPS:     true,
+			// The TLS-only MailDev/greenmail instance embeds a self-signed
+			// certificate which can't be retrieved.
+			TLSConfig: commoncfg.TLSConfig{InsecureSkipVerify: true},
+		},
+		c.Server,
+	)
+	require.NoError(t, err)
+}
+
+// TestEmailNotifyWithDKIM sends an email signed with a DKIM key and checks
+// that MailDev received a DKIM-Signature header.
+func TestEmailNotifyWithDKIM(t *testing.T) {
+	cfgFile := os.Getenv(emailNoAuthConfigVar)
+	if len(cfgFile) == 0 {
+		t.Skipf("%s not set", emailNoAuthConfigVar)
+	}
+
+	c, err := loadEmailTestConfiguration(cfgFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyFile := writeTestDKIMKey(t)
+
+	e, _, err := notifyEmail(
+		&config.EmailConfig{
+			Smarthost: c.Smarthost,
+			To:        emailTo,
+			From:      emailFrom,
+			HTML:      "HTML body",
+			Text:      "Text body",
+			DKIM: &config.DKIMConfig{
+				Selector:         "alertmanager",
+				Domain:           "example.com",
+				PrivateKeyFile:   keyFile,
+				Headers:          config.DefaultDKIMHeaders,
+				Canonicalization: "relaxed/relaxed",
+			},
+		},
+		c.Server,
+	)
+	require.NoError(t, err)
+
+	_, ok := e.Headers["dkim-signature"]
+	require.True(t, ok, "expected a DKIM-Signature header, got %v", e.Headers)
+}
+
 // TestEmailNotifyWithAuthentication sends emails to an instance of MailDev
 // configured with authentication.
 func TestEmailNotifyWithAuthentication(t *testing.T) {
@@ -409,6 +484,132 @@ func TestEmailNotifyWithAuthentication(t *testing.T) {
 	}
 }
 
+// TestEmailNotifyWithXOAUTH2 sends an email through a relay that only
+// accepts XOAUTH2, such as Gmail or Office365. It requires a server speaking
+// XOAUTH2 (e.g. a MailDev fork with the mailin-xoauth2 patch) and a live
+// bearer token, so it is only run when explicitly configured.
+func TestEmailNotifyWithXOAUTH2(t *testing.T) {
+	cfgFile := os.Getenv(emailXOAUTH2ConfigVar)
+	if len(cfgFile) == 0 {
+		t.Skipf("%s not set", emailXOAUTH2ConfigVar)
+	}
+
+	c, err := loadEmailTestConfiguration(cfgFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = notifyEmail(
+		&config.EmailConfig{
+			Smarthost:    c.Smarthost,
+			To:           emailTo,
+			From:         emailFrom,
+			HTML:         "HTML body",
+			Text:         "Text body",
+			AuthUsername: c.Username,
+			AuthTokenSource: &config.AuthTokenSource{
+				Token: config.NewSecret(c.Password),
+			},
+		},
+		c.Server,
+	)
+	require.NoError(t, err)
+}
+
+// newTestEmail builds an Email notifier whose dialer always returns the
+// given mock client, so Notify can be exercised without a real SMTP server.
+func newTestEmail(t *testing.T, conf *config.EmailConfig, client *mockSMTPClient) *Email {
+	t.Helper()
+	if conf.Smarthost == "" {
+		conf.Smarthost = "smtp.example.com:25"
+	}
+	if conf.From == "" {
+		conf.From = emailFrom
+	}
+	if conf.To == "" {
+		conf.To = emailTo
+	}
+	if conf.Headers == nil {
+		conf.Headers = make(map[string]string)
+	}
+	email, err := NewEmail(conf, &template.Template{}, log.NewNopLogger())
+	require.NoError(t, err)
+	email.dialer = func(context.Context, *config.EmailConfig) (SMTPClient, error) {
+		return client, nil
+	}
+	return email
+}
+
+func testAlert() *types.Alert {
+	return &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{},
+			StartsAt: time.Now(),
+			EndsAt:   time.Now().Add(time.Hour),
+		},
+	}
+}
+
+// TestEmailNotifyMultipleFromAddresses checks that a malformed From header
+// is rejected before a connection is ever attempted, and isn't retried.
+func TestEmailNotifyMultipleFromAddresses(t *testing.T) {
+	email := newTestEmail(t, &config.EmailConfig{
+		From: strings.Join([]string{emailFrom, emailTo}, ","),
+	}, newMockSMTP())
+
+	retry, err := email.Notify(context.Background(), testAlert())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "must be exactly one from address")
+	require.False(t, retry)
+}
+
+// TestEmailNotifyInvalidHello checks that a HELO/EHLO rejected by the
+// server is surfaced and retried.
+func TestEmailNotifyInvalidHello(t *testing.T) {
+	client := newMockSMTP()
+	client.helloErr = fmt.Errorf("501 Error: invalid HELO")
+
+	email := newTestEmail(t, &config.EmailConfig{Hello: "invalid hello string"}, client)
+
+	retry, err := email.Notify(context.Background(), testAlert())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "501 Error")
+	require.True(t, retry)
+}
+
+// TestEmailNotifySTARTTLSRequiredButNotAdvertised checks that Notify fails
+// and retries when RequireTLS is set but the server doesn't advertise
+// STARTTLS.
+func TestEmailNotifySTARTTLSRequiredButNotAdvertised(t *testing.T) {
+	client := newMockSMTP()
+	delete(client.extensions, "AUTH")
+
+	trueVar := true
+	email := newTestEmail(t, &config.EmailConfig{RequireTLS: &trueVar}, client)
+
+	retry, err := email.Notify(context.Background(), testAlert())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "does not advertise the STARTTLS extension")
+	require.True(t, retry)
+}
+
+// TestEmailNotifyWrongCredentials checks that an auth rejection from the
+// server is surfaced and retried.
+func TestEmailNotifyWrongCredentials(t *testing.T) {
+	client := newMockSMTP()
+	client.authErr = fmt.Errorf("535 Invalid username or password")
+
+	email := newTestEmail(t, &config.EmailConfig{
+		AuthUsername: "user",
+		AuthPassword: config.NewSecret("wrong"),
+	}, client)
+
+	retry, err := email.Notify(context.Background(), testAlert())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Invalid username or password")
+	require.True(t, retry)
+}
+
 func TestEmailConfigNoAuthMechs(t *testing.T) {
 	email := &Email{
 		conf: &config.EmailConfig{AuthUsername: "test"}, tmpl: &template.Template{}, logger: log.NewNopLogger(),
@@ -448,3 +649,120 @@ func TestEmailNoUsernameStillOk(t *testing.T) {
 	require.NoError(t, err)
 	require.Nil(t, a)
 }
+
+func TestEmailConfigMissingAuthTokenSource(t *testing.T) {
+	conf := &config.EmailConfig{AuthUsername: "test"}
+	email := &Email{
+		conf: conf, tmpl: &template.Template{}, logger: log.NewNopLogger(),
+	}
+	_, err := email.auth("XOAUTH2")
+	require.Error(t, err)
+	require.Equal(t, "missing auth_token_source for XOAUTH2 auth mechanism", err.Error())
+}
+
+// TestEmailXOAUTH2TokenRefresh exercises the file-backed token source: it
+// must pick up a rotated token once the refresh interval elapses, but keep
+// serving the cached value until then.
+func TestEmailXOAUTH2TokenRefresh(t *testing.T) {
+	f, err := ioutil.TempFile("", "xoauth2-token")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString("first-token")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	email := &Email{
+		conf: &config.EmailConfig{
+			AuthUsername: "test",
+			AuthTokenSource: &config.AuthTokenSource{
+				TokenFile:       f.Name(),
+				RefreshInterval: model.Duration(time.Hour),
+			},
+		},
+		tmpl:   &template.Template{},
+		logger: log.NewNopLogger(),
+	}
+
+	a, err := email.auth("XOAUTH2")
+	require.NoError(t, err)
+
+	proto, resp, err := a.Start(&smtp.ServerInfo{})
+	require.NoError(t, err)
+	require.Equal(t, "XOAUTH2", proto)
+	require.Equal(t, "user=test\x01auth=Bearer first-token\x01\x01", string(resp))
+
+	// Rotate the token on disk; within the refresh interval the cached
+	// value must still be served.
+	require.NoError(t, ioutil.WriteFile(f.Name(), []byte("second-token"), 0o600))
+	_, resp, err = a.Start(&smtp.ServerInfo{})
+	require.NoError(t, err)
+	require.Equal(t, "user=test\x01auth=Bearer first-token\x01\x01", string(resp))
+}
+
+// TestEmailXOAUTH2TokenRefreshAfterExpiry checks that once the refresh
+// interval actually elapses, the cached token is dropped and the rotated
+// value on disk is picked up.
+func TestEmailXOAUTH2TokenRefreshAfterExpiry(t *testing.T) {
+	f, err := ioutil.TempFile("", "xoauth2-token")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString("first-token")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	email := &Email{
+		conf: &config.EmailConfig{
+			AuthUsername: "test",
+			AuthTokenSource: &config.AuthTokenSource{
+				TokenFile:       f.Name(),
+				RefreshInterval: model.Duration(10 * time.Millisecond),
+			},
+		},
+		tmpl:   &template.Template{},
+		logger: log.NewNopLogger(),
+	}
+
+	a, err := email.auth("XOAUTH2")
+	require.NoError(t, err)
+
+	_, resp, err := a.Start(&smtp.ServerInfo{})
+	require.NoError(t, err)
+	require.Equal(t, "user=test\x01auth=Bearer first-token\x01\x01", string(resp))
+
+	require.NoError(t, ioutil.WriteFile(f.Name(), []byte("second-token"), 0o600))
+	time.Sleep(20 * time.Millisecond)
+
+	_, resp, err = a.Start(&smtp.ServerInfo{})
+	require.NoError(t, err)
+	require.Equal(t, "user=test\x01auth=Bearer second-token\x01\x01", string(resp))
+}
+
+// TestEmailXOAUTH2ServerRejection mimics Gmail's behaviour of sending a
+// second, base64-decoded challenge carrying a JSON error blob when
+// authentication fails; it must be surfaced as an error.
+func TestEmailXOAUTH2ServerRejection(t *testing.T) {
+	email := &Email{
+		conf: &config.EmailConfig{
+			AuthUsername: "test",
+			AuthTokenSource: &config.AuthTokenSource{
+				Token: config.NewSecret("a-token"),
+			},
+		},
+		tmpl:   &template.Template{},
+		logger: log.NewNopLogger(),
+	}
+
+	a, err := email.auth("XOAUTH2")
+	require.NoError(t, err)
+
+	errBlob := []byte(`{"status":"401","schemes":"bearer","scope":"https://mail.google.com/"}`)
+	_, err = a.Next(errBlob, true)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), string(errBlob))
+
+	// A final, non-challenge round-trip (more == false) must not error.
+	_, err = a.Next(nil, false)
+	require.NoError(t, err)
+}
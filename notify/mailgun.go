@@ -0,0 +1,166 @@
+// Copyright 2019 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	commoncfg "github.com/prometheus/common/config"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// Mailgun notifier, sending mail through the Mailgun HTTP API rather than
+// SMTP. It shares the Email notifier's template pipeline so message content
+// is configured the same way.
+type Mailgun struct {
+	conf    *config.MailgunConfig
+	tmpl    *template.Template
+	logger  log.Logger
+	client  *http.Client
+	retrier *Retrier
+
+	// apiURL returns the Mailgun "messages" endpoint to post to. Tests
+	// override it to point at a local httptest server instead of a real
+	// Mailgun host.
+	apiURL func(conf *config.MailgunConfig) string
+}
+
+// NewMailgun returns a new Mailgun notifier.
+func NewMailgun(c *config.MailgunConfig, t *template.Template, l log.Logger) (*Mailgun, error) {
+	httpConfig := c.HTTPConfig
+	if httpConfig == nil {
+		httpConfig = &commoncfg.HTTPClientConfig{}
+	}
+	client, err := commoncfg.NewClientFromConfig(*httpConfig, "mailgun", false, false)
+	if err != nil {
+		return nil, err
+	}
+	return &Mailgun{
+		conf:    c,
+		tmpl:    t,
+		logger:  l,
+		client:  client,
+		retrier: &Retrier{},
+		apiURL:  defaultMailgunAPIURL,
+	}, nil
+}
+
+// defaultMailgunAPIURL is Mailgun.apiURL's default: the real Mailgun API.
+func defaultMailgunAPIURL(c *config.MailgunConfig) string {
+	return fmt.Sprintf("https://%s/v3/%s/messages", c.APIHost(), c.Domain)
+}
+
+// Notify implements the Notifier interface.
+func (n *Mailgun) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	data := n.tmpl.Data("mailgun", as...)
+
+	tmplText := n.tmpl.ExecuteTextString
+	var tmplErr error
+	tmpl := func(name, s string) string {
+		v, err := tmplText(s, data)
+		if err != nil && tmplErr == nil {
+			tmplErr = fmt.Errorf("executing %q: %w", name, err)
+		}
+		return v
+	}
+
+	subject := tmpl("subject", n.conf.Subject)
+	text := tmpl("text", n.conf.Text)
+	if tmplErr != nil {
+		return false, tmplErr
+	}
+	html, err := n.tmpl.ExecuteHTMLString(n.conf.HTML, data)
+	if err != nil {
+		return false, fmt.Errorf("executing html: %w", err)
+	}
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	writeField := func(name, value string) error {
+		if value == "" {
+			return nil
+		}
+		return w.WriteField(name, value)
+	}
+	if err := writeField("to", n.conf.To); err != nil {
+		return false, err
+	}
+	if err := writeField("from", n.conf.From); err != nil {
+		return false, err
+	}
+	if err := writeField("subject", subject); err != nil {
+		return false, err
+	}
+	if err := writeField("text", text); err != nil {
+		return false, err
+	}
+	if err := writeField("html", html); err != nil {
+		return false, err
+	}
+	for h, v := range n.conf.Headers {
+		if err := writeField("h:"+h, tmpl(h, v)); err != nil {
+			return false, err
+		}
+	}
+	if tmplErr != nil {
+		return false, tmplErr
+	}
+	for _, t := range n.conf.Tags {
+		if err := writeField("o:tag", t); err != nil {
+			return false, err
+		}
+	}
+	if err := writeField("o:tracking", yesNo(n.conf.Tracking)); err != nil {
+		return false, err
+	}
+	if err := w.Close(); err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.apiURL(n.conf), &buf)
+	if err != nil {
+		return true, err
+	}
+	req.SetBasicAuth("api", string(n.conf.APIKey))
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	level.Debug(n.logger).Log("msg", "sent mailgun notification", "to", n.conf.To, "status", resp.StatusCode)
+
+	return n.retrier.Check(resp.StatusCode, resp.Body)
+}
+
+// yesNo renders a bool the way Mailgun's "o:*" message parameters expect,
+// e.g. "o:tracking" — "yes"/"no" rather than Go's "true"/"false".
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
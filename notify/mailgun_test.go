@@ -0,0 +1,183 @@
+// Copyright 2019 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// TestMailgunNotify sends a real message through the Mailgun HTTP API. It is
+// only executed when pointed at a sandbox domain, since it requires a live
+// Mailgun account; otherwise it is skipped.
+//
+// $ MAILGUN_API_KEY=key-xxxx MAILGUN_DOMAIN=sandboxxxx.mailgun.org MAILGUN_TO=alerts@example.com go test ./notify/...
+package notify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	commoncfg "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+)
+
+const (
+	mailgunAPIKeyVar = "MAILGUN_API_KEY"
+	mailgunDomainVar = "MAILGUN_DOMAIN"
+	mailgunToVar     = "MAILGUN_TO"
+)
+
+func TestMailgunNotify(t *testing.T) {
+	apiKey := os.Getenv(mailgunAPIKeyVar)
+	domain := os.Getenv(mailgunDomainVar)
+	to := os.Getenv(mailgunToVar)
+	if apiKey == "" || domain == "" || to == "" {
+		t.Skipf("%s, %s or %s not set", mailgunAPIKeyVar, mailgunDomainVar, mailgunToVar)
+	}
+
+	cfg := &config.MailgunConfig{
+		APIKey:     config.NewSecret(apiKey),
+		Domain:     domain,
+		Region:     "us",
+		To:         to,
+		From:       "alertmanager@" + domain,
+		Subject:    "{{ len .Alerts }} {{ .Status }} alert(s)",
+		Text:       "Text body",
+		Tags:       []string{"alertmanager-test"},
+		Tracking:   false,
+		HTTPConfig: &commoncfg.HTTPClientConfig{},
+	}
+
+	tmpl, err := template.FromGlobs()
+	require.NoError(t, err)
+	tmpl.ExternalURL, _ = url.Parse("http://am")
+
+	mg, err := NewMailgun(cfg, tmpl, log.NewNopLogger())
+	require.NoError(t, err)
+
+	firingAlert := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{},
+			StartsAt: time.Now(),
+			EndsAt:   time.Now().Add(time.Hour),
+		},
+	}
+
+	_, err = mg.Notify(context.Background(), firingAlert)
+	require.NoError(t, err)
+}
+
+// TestMailgunNotifyPostsExpectedFields checks the form fields posted to the
+// Mailgun API against a local httptest server, since TestMailgunNotify above
+// only ever runs against a live Mailgun account.
+func TestMailgunNotifyPostsExpectedFields(t *testing.T) {
+	var gotForm url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		gotForm = r.Form
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	cfg := &config.MailgunConfig{
+		APIKey:     config.NewSecret("key-test"),
+		Domain:     "example.com",
+		Region:     "us",
+		To:         "alerts@example.com",
+		From:       "alertmanager@example.com",
+		Subject:    "{{ len .Alerts }} {{ .Status }} alert(s)",
+		Text:       "Text body",
+		HTML:       "HTML body",
+		Headers:    map[string]string{"X-Test": "header value"},
+		Tags:       []string{"alertmanager-test", "env:prod"},
+		Tracking:   true,
+		HTTPConfig: &commoncfg.HTTPClientConfig{},
+	}
+
+	tmpl, err := template.FromGlobs()
+	require.NoError(t, err)
+	tmpl.ExternalURL, _ = url.Parse("http://am")
+
+	mg, err := NewMailgun(cfg, tmpl, log.NewNopLogger())
+	require.NoError(t, err)
+	mg.apiURL = func(*config.MailgunConfig) string { return srv.URL }
+
+	_, err = mg.Notify(context.Background(), testAlert())
+	require.NoError(t, err)
+
+	require.Equal(t, "alerts@example.com", gotForm.Get("to"))
+	require.Equal(t, "alertmanager@example.com", gotForm.Get("from"))
+	require.Equal(t, "1 firing alert(s)", gotForm.Get("subject"))
+	require.Equal(t, "Text body", gotForm.Get("text"))
+	require.Equal(t, "HTML body", gotForm.Get("html"))
+	require.Equal(t, "header value", gotForm.Get("h:X-Test"))
+	require.ElementsMatch(t, []string{"alertmanager-test", "env:prod"}, gotForm["o:tag"])
+	require.Equal(t, "yes", gotForm.Get("o:tracking"))
+}
+
+// TestMailgunNotifyTrackingDisabled checks that a disabled Tracking option
+// is posted as "no", not Go's "false".
+func TestMailgunNotifyTrackingDisabled(t *testing.T) {
+	var gotForm url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		gotForm = r.Form
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	cfg := &config.MailgunConfig{
+		APIKey:     config.NewSecret("key-test"),
+		Domain:     "example.com",
+		Region:     "us",
+		To:         "alerts@example.com",
+		From:       "alertmanager@example.com",
+		Text:       "Text body",
+		Tracking:   false,
+		HTTPConfig: &commoncfg.HTTPClientConfig{},
+	}
+
+	tmpl, err := template.FromGlobs()
+	require.NoError(t, err)
+	tmpl.ExternalURL, _ = url.Parse("http://am")
+
+	mg, err := NewMailgun(cfg, tmpl, log.NewNopLogger())
+	require.NoError(t, err)
+	mg.apiURL = func(*config.MailgunConfig) string { return srv.URL }
+
+	_, err = mg.Notify(context.Background(), testAlert())
+	require.NoError(t, err)
+	require.Equal(t, "no", gotForm.Get("o:tracking"))
+}
+
+// TestMailgunConfigMissingHTTPConfigStillWorks checks that a MailgunConfig
+// built directly in Go, without going through YAML unmarshaling, doesn't
+// panic when HTTPConfig is left nil.
+func TestMailgunConfigMissingHTTPConfigStillWorks(t *testing.T) {
+	tmpl, err := template.FromGlobs()
+	require.NoError(t, err)
+
+	_, err = NewMailgun(&config.MailgunConfig{
+		APIKey: config.NewSecret("key-test"),
+		Domain: "example.com",
+		Region: "us",
+		To:     "alerts@example.com",
+	}, tmpl, log.NewNopLogger())
+	require.NoError(t, err)
+}
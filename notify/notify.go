@@ -0,0 +1,72 @@
+// Copyright 2019 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package notify implements the delivery of alert notifications to the
+// configured receivers.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/prometheus/alertmanager/types"
+)
+
+// Notifier notifies about alerts under constraints of the given context. It
+// returns an error if the notification was not sent, and true if that error
+// is recoverable and the notification should be retried.
+type Notifier interface {
+	Notify(ctx context.Context, alert ...*types.Alert) (bool, error)
+}
+
+// Retrier knows when to retry an HTTP request and how to report its errors.
+type Retrier struct {
+	// RetryCodes is optional. If empty, only 5xx status codes are retried.
+	RetryCodes []int
+}
+
+// Check returns whether the request should be retried and an error if the
+// response was not successful.
+func (r *Retrier) Check(statusCode int, body io.Reader) (bool, error) {
+	if r.retryable(statusCode) {
+		return true, r.errorf(statusCode, body)
+	}
+	if statusCode/100 != 2 {
+		return false, r.errorf(statusCode, body)
+	}
+	return false, nil
+}
+
+func (r *Retrier) retryable(statusCode int) bool {
+	if len(r.RetryCodes) == 0 {
+		return statusCode/100 == 5
+	}
+	for _, code := range r.RetryCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Retrier) errorf(statusCode int, body io.Reader) error {
+	const maxBody = 1024
+	var s string
+	if body != nil {
+		b, _ := ioutil.ReadAll(io.LimitReader(body, maxBody))
+		s = string(b)
+	}
+	return fmt.Errorf("unexpected status code %d: %s", statusCode, s)
+}
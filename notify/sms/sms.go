@@ -0,0 +1,142 @@
+// Copyright 2019 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sms implements the SMS notifier, following the same
+// template-pipeline-plus-pluggable-provider pattern as the Email notifier.
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	commoncfg "github.com/prometheus/common/config"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// maxBodyLen is the number of characters a single SMS provider message is
+// truncated to.
+const maxBodyLen = 1600
+
+// SMS notifier.
+type SMS struct {
+	conf    *config.SMSConfig
+	tmpl    *template.Template
+	logger  log.Logger
+	client  *http.Client
+	retrier *notify.Retrier
+}
+
+// NewSMS returns a new SMS notifier.
+func NewSMS(c *config.SMSConfig, t *template.Template, l log.Logger) (*SMS, error) {
+	httpConfig := c.HTTPConfig
+	if httpConfig == nil {
+		httpConfig = &commoncfg.HTTPClientConfig{}
+	}
+	client, err := commoncfg.NewClientFromConfig(*httpConfig, "sms", false, false)
+	if err != nil {
+		return nil, err
+	}
+	return &SMS{
+		conf:    c,
+		tmpl:    t,
+		logger:  l,
+		client:  client,
+		retrier: &notify.Retrier{},
+	}, nil
+}
+
+// Notify implements the notify.Notifier interface.
+func (n *SMS) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	data := n.tmpl.Data("sms", as...)
+
+	body, err := n.tmpl.ExecuteTextString(n.conf.Body, data)
+	if err != nil {
+		return false, fmt.Errorf("executing body: %w", err)
+	}
+	if r := []rune(body); len(r) > maxBodyLen {
+		body = string(r[:maxBodyLen])
+	}
+
+	var (
+		retry bool
+		errs  []string
+	)
+	for _, to := range strings.Split(n.conf.To, ",") {
+		to = strings.TrimSpace(to)
+		if to == "" {
+			continue
+		}
+		r, err := n.send(ctx, to, body)
+		if err != nil {
+			retry = retry || r
+			errs = append(errs, fmt.Sprintf("%s: %s", to, err))
+		}
+	}
+	if len(errs) > 0 {
+		return retry, errors.New(strings.Join(errs, "; "))
+	}
+	return false, nil
+}
+
+// send delivers body to a single recipient through the configured provider.
+func (n *SMS) send(ctx context.Context, to, body string) (bool, error) {
+	switch n.conf.Provider {
+	case config.SMSProviderTwilio:
+		return n.sendTwilio(ctx, to, body)
+	default:
+		// config.SMSConfig.UnmarshalYAML rejects every other provider at
+		// load time, so this only fires for configs built directly in Go.
+		return false, fmt.Errorf("unsupported sms provider %q", n.conf.Provider)
+	}
+}
+
+// sendTwilio posts to the Twilio Messages resource. See
+// https://www.twilio.com/docs/sms/api/message-resource.
+func (n *SMS) sendTwilio(ctx context.Context, to, body string) (bool, error) {
+	apiURL := n.conf.APIURL
+	if apiURL == "" {
+		apiURL = fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json", string(n.conf.TwilioAccountSID))
+	}
+
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", n.conf.From)
+	form.Set("Body", body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return true, err
+	}
+	req.SetBasicAuth(string(n.conf.TwilioAccountSID), string(n.conf.TwilioAuthToken))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	level.Debug(n.logger).Log("msg", "sent sms notification", "to", to, "status", resp.StatusCode)
+
+	return n.retrier.Check(resp.StatusCode, resp.Body)
+}
@@ -0,0 +1,227 @@
+// Copyright 2019 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// TestSMSNotifyAgainstMockServer sends a real SMS to a locally-run Twilio
+// API mock. Point SMS_MOCK_SERVER_URL at it to run it; otherwise it is
+// skipped. The remaining tests in this file use an in-process httptest
+// server instead, so they exercise the same code paths without requiring
+// anything to be started out of band.
+package sms
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+)
+
+const smsMockServerURLVar = "SMS_MOCK_SERVER_URL"
+
+// TestSMSNotifyAgainstMockServer sends a real SMS to a mock server started
+// out of band (e.g. twilio-mock or a small custom stub), pointed at via
+// SMS_MOCK_SERVER_URL. This mirrors the MailDev-based email integration
+// tests but for the SMS notifier.
+func TestSMSNotifyAgainstMockServer(t *testing.T) {
+	mockURL := os.Getenv(smsMockServerURLVar)
+	if mockURL == "" {
+		t.Skipf("%s not set", smsMockServerURLVar)
+	}
+
+	sms := newTestSMS(t, &config.SMSConfig{
+		Provider:         config.SMSProviderTwilio,
+		To:               "+15551234567",
+		From:             "+15557654321",
+		Body:             "{{ len .Alerts }} {{ .Status }} alert(s)",
+		TwilioAccountSID: config.NewSecret("SID"),
+		TwilioAuthToken:  config.NewSecret("TOKEN"),
+		APIURL:           mockURL,
+	})
+
+	_, err := sms.Notify(context.Background(), testAlert())
+	require.NoError(t, err)
+}
+
+func testAlert() *types.Alert {
+	return &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{},
+			StartsAt: time.Now(),
+			EndsAt:   time.Now().Add(time.Hour),
+		},
+	}
+}
+
+// TestSMSNotifyRendersBodyTemplate checks that Body is rendered through the
+// shared template pipeline before being sent.
+func TestSMSNotifyRendersBodyTemplate(t *testing.T) {
+	var gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		gotBody = r.Form.Get("Body")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	sms := newTestSMS(t, &config.SMSConfig{
+		Provider:         config.SMSProviderTwilio,
+		To:               "+15551234567",
+		From:             "+15557654321",
+		Body:             "{{ len .Alerts }} {{ .Status }} alert(s)",
+		TwilioAccountSID: config.NewSecret("SID"),
+		TwilioAuthToken:  config.NewSecret("TOKEN"),
+		APIURL:           srv.URL,
+	})
+
+	_, err := sms.Notify(context.Background(), testAlert())
+	require.NoError(t, err)
+	require.Equal(t, "1 firing alert(s)", gotBody)
+}
+
+// TestSMSNotifyTruncatesBody checks that a body longer than 1600 characters
+// is truncated before being sent, matching common carrier limits.
+func TestSMSNotifyTruncatesBody(t *testing.T) {
+	var gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		gotBody = r.Form.Get("Body")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	sms := newTestSMS(t, &config.SMSConfig{
+		Provider:         config.SMSProviderTwilio,
+		To:               "+15551234567",
+		From:             "+15557654321",
+		Body:             strings.Repeat("x", 2000),
+		TwilioAccountSID: config.NewSecret("SID"),
+		TwilioAuthToken:  config.NewSecret("TOKEN"),
+		APIURL:           srv.URL,
+	})
+
+	_, err := sms.Notify(context.Background(), testAlert())
+	require.NoError(t, err)
+	require.Len(t, gotBody, 1600)
+}
+
+// TestSMSNotifyMultiRecipientFanout checks that a comma-separated To list
+// results in one request per recipient.
+func TestSMSNotifyMultiRecipientFanout(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		got = append(got, r.Form.Get("To"))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	sms := newTestSMS(t, &config.SMSConfig{
+		Provider:         config.SMSProviderTwilio,
+		To:               "+15551234567, +15559876543",
+		From:             "+15557654321",
+		Body:             "alert fired",
+		TwilioAccountSID: config.NewSecret("SID"),
+		TwilioAuthToken:  config.NewSecret("TOKEN"),
+		APIURL:           srv.URL,
+	})
+
+	_, err := sms.Notify(context.Background(), testAlert())
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"+15551234567", "+15559876543"}, got)
+}
+
+// TestSMSNotify4xxNotRetried checks that a 4xx response from the provider
+// is surfaced as a non-retryable error.
+func TestSMSNotify4xxNotRetried(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid number", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	sms := newTestSMS(t, &config.SMSConfig{
+		Provider:         config.SMSProviderTwilio,
+		To:               "+15551234567",
+		From:             "+15557654321",
+		Body:             "alert fired",
+		TwilioAccountSID: config.NewSecret("SID"),
+		TwilioAuthToken:  config.NewSecret("TOKEN"),
+		APIURL:           srv.URL,
+	})
+
+	retry, err := sms.Notify(context.Background(), testAlert())
+	require.Error(t, err)
+	require.False(t, retry)
+}
+
+// TestSMSNotify5xxRetried checks that a 5xx response from the provider is
+// surfaced as a retryable error.
+func TestSMSNotify5xxRetried(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "server error", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	sms := newTestSMS(t, &config.SMSConfig{
+		Provider:         config.SMSProviderTwilio,
+		To:               "+15551234567",
+		From:             "+15557654321",
+		Body:             "alert fired",
+		TwilioAccountSID: config.NewSecret("SID"),
+		TwilioAuthToken:  config.NewSecret("TOKEN"),
+		APIURL:           srv.URL,
+	})
+
+	retry, err := sms.Notify(context.Background(), testAlert())
+	require.Error(t, err)
+	require.True(t, retry)
+}
+
+// TestSMSConfigMissingHTTPConfigStillWorks checks that an SMSConfig built
+// directly in Go, without going through YAML unmarshaling, doesn't panic
+// when HTTPConfig is left nil.
+func TestSMSConfigMissingHTTPConfigStillWorks(t *testing.T) {
+	tmpl, err := template.FromGlobs()
+	require.NoError(t, err)
+
+	_, err = NewSMS(&config.SMSConfig{
+		Provider:         config.SMSProviderTwilio,
+		To:               "+15551234567",
+		From:             "+15557654321",
+		TwilioAccountSID: config.NewSecret("SID"),
+		TwilioAuthToken:  config.NewSecret("TOKEN"),
+		HTTPConfig:       nil,
+	}, tmpl, log.NewNopLogger())
+	require.NoError(t, err)
+}
+
+func newTestSMS(t *testing.T, c *config.SMSConfig) *SMS {
+	t.Helper()
+	tmpl, err := template.FromGlobs()
+	require.NoError(t, err)
+	tmpl.ExternalURL, _ = url.Parse("http://am")
+
+	sms, err := NewSMS(c, tmpl, log.NewNopLogger())
+	require.NoError(t, err)
+	return sms
+}
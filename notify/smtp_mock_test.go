@@ -0,0 +1,99 @@
+// Copyright 2019 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io"
+	"net/smtp"
+)
+
+// mockSMTPClient is a scriptable SMTPClient used to exercise Email.Notify
+// without a real SMTP server.
+type mockSMTPClient struct {
+	// extensions maps an advertised extension name to its parameter
+	// string; an absent key means the server doesn't support it.
+	extensions map[string]string
+
+	helloErr    error
+	starttlsErr error
+	authErr     error
+	mailErr     error
+	rcptErr     error
+	dataErr     error
+	quitErr     error
+
+	helloArg string
+	data     bytes.Buffer
+	closed   bool
+}
+
+// newMockSMTP returns a mock SMTP server that advertises PLAIN and LOGIN
+// auth and accepts everything else.
+func newMockSMTP() *mockSMTPClient {
+	return &mockSMTPClient{
+		extensions: map[string]string{"AUTH": "PLAIN LOGIN"},
+	}
+}
+
+func (m *mockSMTPClient) Hello(localName string) error {
+	m.helloArg = localName
+	return m.helloErr
+}
+
+func (m *mockSMTPClient) Extension(ext string) (bool, string) {
+	p, ok := m.extensions[ext]
+	return ok, p
+}
+
+func (m *mockSMTPClient) StartTLS(*tls.Config) error {
+	return m.starttlsErr
+}
+
+func (m *mockSMTPClient) Auth(smtp.Auth) error {
+	return m.authErr
+}
+
+func (m *mockSMTPClient) Mail(string) error {
+	return m.mailErr
+}
+
+func (m *mockSMTPClient) Rcpt(string) error {
+	return m.rcptErr
+}
+
+func (m *mockSMTPClient) Data() (io.WriteCloser, error) {
+	if m.dataErr != nil {
+		return nil, m.dataErr
+	}
+	return nopWriteCloser{&m.data}, nil
+}
+
+func (m *mockSMTPClient) Quit() error {
+	return m.quitErr
+}
+
+func (m *mockSMTPClient) Close() error {
+	m.closed = true
+	return nil
+}
+
+// nopWriteCloser adapts an io.Writer into an io.WriteCloser whose Close is a
+// no-op, mirroring the buffering *smtp.Client.Data() does internally.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
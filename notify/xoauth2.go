@@ -0,0 +1,109 @@
+// Copyright 2019 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/config"
+)
+
+// defaultTokenRefreshInterval is used when AuthTokenSource.RefreshInterval
+// is unset.
+const defaultTokenRefreshInterval = 5 * time.Minute
+
+// bearerTokenSource returns the bearer token to present for XOAUTH2 auth.
+type bearerTokenSource interface {
+	Token() (string, error)
+}
+
+// newBearerTokenSource builds a bearerTokenSource from an
+// AuthTokenSource config.
+func newBearerTokenSource(c *config.AuthTokenSource) (bearerTokenSource, error) {
+	if len(c.Token) > 0 {
+		return staticToken(c.Token), nil
+	}
+	interval := time.Duration(c.RefreshInterval)
+	if interval <= 0 {
+		interval = defaultTokenRefreshInterval
+	}
+	return &fileTokenSource{path: c.TokenFile, refreshInterval: interval}, nil
+}
+
+// staticToken is a bearerTokenSource backed by a fixed secret.
+type staticToken string
+
+func (t staticToken) Token() (string, error) {
+	return string(t), nil
+}
+
+// fileTokenSource is a bearerTokenSource that re-reads the token from disk
+// at most once per refreshInterval, so that an external process can rotate
+// the token without restarting Alertmanager.
+type fileTokenSource struct {
+	path            string
+	refreshInterval time.Duration
+
+	mtx       sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func (t *fileTokenSource) Token() (string, error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if t.token != "" && time.Now().Before(t.expiresAt) {
+		return t.token, nil
+	}
+	b, err := ioutil.ReadFile(t.path)
+	if err != nil {
+		return "", fmt.Errorf("reading auth token file: %w", err)
+	}
+	t.token = strings.TrimSpace(string(b))
+	t.expiresAt = time.Now().Add(t.refreshInterval)
+	return t.token, nil
+}
+
+// xoauth2Auth implements smtp.Auth for the XOAUTH2 mechanism used by Gmail
+// and Office365 relays in place of a plain password.
+type xoauth2Auth struct {
+	username string
+	source   bearerTokenSource
+}
+
+func (a *xoauth2Auth) Start(_ *smtp.ServerInfo) (string, []byte, error) {
+	token, err := a.source.Token()
+	if err != nil {
+		return "", nil, err
+	}
+	resp := fmt.Sprintf("user=%s\x01auth=Bearer %s\x01\x01", a.username, token)
+	return "XOAUTH2", []byte(resp), nil
+}
+
+// Next handles the server's response to the initial challenge. On success
+// the server closes the exchange (more is false on the final OK); on
+// failure it sends a second challenge carrying a JSON error blob, which
+// net/smtp has already base64-decoded and which we surface as an error.
+func (a *xoauth2Auth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("XOAUTH2 authentication failed: %s", fromServer)
+}
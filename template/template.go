@@ -0,0 +1,127 @@
+// Copyright 2019 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package template renders the notification templates shared by all
+// notifiers in the notify package.
+package template
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"net/url"
+	texttemplate "text/template"
+
+	"github.com/prometheus/alertmanager/types"
+)
+
+// Template bundles the parsed text and HTML templates used to render
+// notifications.
+type Template struct {
+	text        *texttemplate.Template
+	html        *htmltemplate.Template
+	ExternalURL *url.URL
+}
+
+// Data is the data passed to notification templates.
+type Data struct {
+	Receiver string
+	Status   string
+	Alerts   []*types.Alert
+
+	ExternalURL string
+}
+
+// FromGlobs parses the templates matched by the given glob patterns and
+// returns a ready to use Template. No default templates are built in:
+// templates such as "email.default.subject" are only defined if one of the
+// matched files defines them.
+func FromGlobs(paths ...string) (*Template, error) {
+	t := &Template{}
+
+	tmpl := texttemplate.New("").Option("missingkey=zero")
+	for _, p := range paths {
+		if _, err := tmpl.ParseGlob(p); err != nil {
+			return nil, err
+		}
+	}
+	t.text = tmpl
+
+	htmpl := htmltemplate.New("").Option("missingkey=zero")
+	for _, p := range paths {
+		if _, err := htmpl.ParseGlob(p); err != nil {
+			return nil, err
+		}
+	}
+	t.html = htmpl
+
+	return t, nil
+}
+
+// ExecuteTextString needs a text template that has a "." data element.
+func (t *Template) ExecuteTextString(text string, data interface{}) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	tmpl, err := t.text.Clone()
+	if err != nil {
+		return "", err
+	}
+	tmpl, err = tmpl.New("").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// ExecuteHTMLString needs a html template that has a "." data element.
+func (t *Template) ExecuteHTMLString(html string, data interface{}) (string, error) {
+	if html == "" {
+		return "", nil
+	}
+	tmpl, err := t.html.Clone()
+	if err != nil {
+		return "", err
+	}
+	tmpl, err = tmpl.New("").Parse(html)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Data assembles the final template data for the given receiver and alerts.
+func (t *Template) Data(receiver string, alerts ...*types.Alert) *Data {
+	data := &Data{
+		Receiver: receiver,
+		Status:   "firing",
+		Alerts:   alerts,
+	}
+	if t.ExternalURL != nil {
+		data.ExternalURL = t.ExternalURL.String()
+	}
+	for _, a := range alerts {
+		if a.Resolved() {
+			data.Status = "resolved"
+			break
+		}
+	}
+	return data
+}